@@ -0,0 +1,66 @@
+// Command dir2opds serves a directory tree as an OPDS catalog.
+package main
+
+import (
+	"flag"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/dubyte/dir2opds/internal/service"
+	"github.com/dubyte/dir2opds/opds/search"
+)
+
+func main() {
+	var (
+		dirRoot         = flag.String("dir", ".", "root directory to serve as an OPDS catalog")
+		addr            = flag.String("addr", ":8080", "address to listen on")
+		author          = flag.String("author", "dir2opds", "feed author name")
+		authorEmail     = flag.String("author-email", "", "feed author email")
+		authorURI       = flag.String("author-uri", "", "feed author URI")
+		cacheDir        = flag.String("cache-dir", "", "directory to cache extracted cover images in")
+		pageSize        = flag.Int("page-size", 0, "entries per page for /latest and /titles (0 disables pagination)")
+		htpasswdFile    = flag.String("htpasswd", "", "htpasswd file to require HTTP Basic auth against")
+		progressDir     = flag.String("progress-dir", "", "file to persist per-user OPDS-PSE reading positions in")
+		convertCacheDir = flag.String("convert-cache-dir", "", "directory to cache on-the-fly KEPUB/MOBI conversions in")
+		reindexInterval = flag.Duration("reindex-interval", 5*time.Minute, "how often to rebuild the catalog index in the background")
+	)
+	flag.Parse()
+
+	s := service.OPDS{
+		DirRoot:         *dirRoot,
+		Author:          *author,
+		AuthorEmail:     *authorEmail,
+		AuthorURI:       *authorURI,
+		CacheDir:        *cacheDir,
+		PageSize:        *pageSize,
+		HtpasswdFile:    *htpasswdFile,
+		ProgressDir:     *progressDir,
+		ConvertCacheDir: *convertCacheDir,
+	}
+
+	stop := make(chan struct{})
+	defer close(stop)
+	go search.Watch(*reindexInterval, stop, s.Reindex)
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/opensearch.xml", handle(s.OpenSearchHandler))
+	mux.HandleFunc("/search", handle(s.WithBasicAuth(s.SearchHandler)))
+	mux.HandleFunc("/covers/", handle(s.WithBasicAuth(s.CoverHandler)))
+	mux.HandleFunc("/progress/", handle(s.WithBasicAuth(s.PositionHandler)))
+	mux.HandleFunc("/", handle(s.WithBasicAuth(s.Handler)))
+
+	log.Printf("dir2opds listening on %s, serving %s", *addr, *dirRoot)
+	log.Fatal(http.ListenAndServe(*addr, mux))
+}
+
+// handle adapts an OPDS handler method, which reports failures via its
+// return value, to the http.HandlerFunc signature http.ServeMux expects.
+func handle(fn func(http.ResponseWriter, *http.Request) error) http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		if err := fn(w, req); err != nil {
+			log.Printf("error serving '%s': %s", req.URL.Path, err)
+			http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+		}
+	}
+}
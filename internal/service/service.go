@@ -1,10 +1,12 @@
-//package service provides a http handler that reads the path in the request.url and returns
+// package service provides a http handler that reads the path in the request.url and returns
 // an xml document that follows the OPDS 1.1 standard
 // https://specs.opds.io/opds-1.1.html
 package service
 
 import (
 	"bytes"
+	"context"
+	"encoding/json"
 	"encoding/xml"
 	"fmt"
 	"io/fs"
@@ -16,10 +18,17 @@ import (
 	"os"
 	"path/filepath"
 	"sort"
+	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/dubyte/dir2opds/opds"
+	"github.com/dubyte/dir2opds/opds/auth"
+	"github.com/dubyte/dir2opds/opds/convert"
+	"github.com/dubyte/dir2opds/opds/metadata"
+	"github.com/dubyte/dir2opds/opds/progress"
+	"github.com/dubyte/dir2opds/opds/search"
 )
 
 func init() {
@@ -37,13 +46,37 @@ const (
 	pathTypeDirOfFiles
 )
 
-var files = []BookFile{}
+// filesMu guards files, which Reindex rewrites wholesale on every "/"
+// request and, concurrently, on every polling background rebuild
+// (see search.Watch and cmd/dir2opds's startup call into it). currentFiles
+// is the only way callers should read it.
+var (
+	filesMu sync.RWMutex
+	files   = []BookFile{}
+)
+
+// currentFiles returns a snapshot of files, safe to read and sort without
+// holding filesMu or racing Reindex's next swap.
+func currentFiles() []BookFile {
+	filesMu.RLock()
+	defer filesMu.RUnlock()
+	snapshot := make([]BookFile, len(files))
+	copy(snapshot, files)
+	return snapshot
+}
+
+// searchIndex backs the OpenSearch endpoint. Like files above, it's rebuilt
+// whenever "/" is requested and is safe for concurrent reads/writes.
+var searchIndex = search.NewIndex()
 
 type BookFile struct {
-	Name     string
-	Path     string
-	Author   string
-	FileInfo fs.FileInfo
+	Name      string
+	Path      string
+	Author    string
+	Title     string
+	Summary   string
+	CoverPath string
+	FileInfo  fs.FileInfo
 }
 
 type OPDS struct {
@@ -51,12 +84,462 @@ type OPDS struct {
 	Author      string
 	AuthorEmail string
 	AuthorURI   string
+	// CacheDir, if set, is where extracted cover thumbnails are cached on
+	// disk. Leave empty to disable caching; metadata is then extracted on
+	// every Reindex and no cover links are emitted or served.
+	CacheDir string
+	// PageSize, if greater than zero, caps how many entries /latest and
+	// /titles return per page; no other feed is paginated.
+	// A zero value keeps the previous unpaginated behaviour.
+	PageSize int
+	// HtpasswdFile, if set, gates every handler behind HTTP Basic auth
+	// checked against this htpasswd-style file. Leave empty to serve
+	// without authentication.
+	HtpasswdFile string
+	// ProgressDir, if set, is the path to the file per-user OPDS-PSE reading
+	// positions are persisted in. Requires HtpasswdFile to also be set,
+	// since positions are scoped to an authenticated user.
+	ProgressDir string
+	// ConvertCacheDir, if set, is where on-the-fly KEPUB/MOBI conversions
+	// are cached. Leave empty to convert on demand without caching.
+	ConvertCacheDir string
+}
+
+func (s OPDS) converter() convert.Converter {
+	return convert.Converter{Dir: s.ConvertCacheDir}
+}
+
+type contextKey string
+
+const authUserContextKey contextKey = "dir2opds-user"
+
+// WithBasicAuth wraps an OPDS handler function with HTTP Basic auth checked
+// against HtpasswdFile. If HtpasswdFile is empty, requests pass through
+// unauthenticated; otherwise the authenticated username is attached to the
+// request context for handlers like PositionHandler to read back.
+func (s OPDS) WithBasicAuth(next func(http.ResponseWriter, *http.Request) error) func(http.ResponseWriter, *http.Request) error {
+	return func(w http.ResponseWriter, req *http.Request) error {
+		if s.HtpasswdFile == "" {
+			return next(w, req)
+		}
+
+		entries, err := auth.LoadHtpasswd(s.HtpasswdFile)
+		if err != nil {
+			log.Printf("error loading htpasswd file '%s': %s", s.HtpasswdFile, err)
+			http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+			return nil
+		}
+
+		user, pass, ok := req.BasicAuth()
+		if !ok || !entries.Verify(user, pass) {
+			w.Header().Set("WWW-Authenticate", `Basic realm="dir2opds"`)
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return nil
+		}
+
+		return next(w, req.WithContext(context.WithValue(req.Context(), authUserContextKey, user)))
+	}
+}
+
+func authUser(req *http.Request) string {
+	user, _ := req.Context().Value(authUserContextKey).(string)
+	return user
+}
+
+// pseLinkRel is the OPDS-PSE stream link relation advertised on acquisition
+// entries so compatible readers can sync reading progress across devices.
+const pseLinkRel = "http://vaemendis.net/opds-pse/stream"
+
+// PositionHandler serves (GET) and accepts (PUT) the OPDS-PSE position
+// document for the book at the path following "/progress/", scoped to the
+// user authenticated by WithBasicAuth.
+func (s OPDS) PositionHandler(w http.ResponseWriter, req *http.Request) error {
+	if s.ProgressDir == "" {
+		http.NotFound(w, req)
+		return nil
+	}
+
+	user := authUser(req)
+	if user == "" {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return nil
+	}
+
+	bookID, err := url.PathUnescape(strings.TrimPrefix(req.URL.Path, "/progress/"))
+	if err != nil {
+		http.Error(w, "bad request", http.StatusBadRequest)
+		return nil
+	}
+	store := progress.Store{Path: s.ProgressDir}
+
+	switch req.Method {
+	case http.MethodGet:
+		pos, err := store.Get(user, bookID)
+		if err != nil {
+			log.Printf("error reading position for '%s'/'%s': %s", user, bookID, err)
+			return err
+		}
+		w.Header().Set("Content-Type", "application/json")
+		return json.NewEncoder(w).Encode(pos)
+
+	case http.MethodPut:
+		var pos progress.Position
+		if err := json.NewDecoder(req.Body).Decode(&pos); err != nil {
+			http.Error(w, "bad request", http.StatusBadRequest)
+			return nil
+		}
+		pos.Updated = TimeNow()
+
+		if err := store.Put(user, bookID, pos); err != nil {
+			log.Printf("error saving position for '%s'/'%s': %s", user, bookID, err)
+			return err
+		}
+		w.WriteHeader(http.StatusNoContent)
+		return nil
+
+	default:
+		w.Header().Set("Allow", "GET, PUT")
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return nil
+	}
+}
+
+func (s OPDS) coverCache() metadata.Cache {
+	return metadata.Cache{Dir: s.CacheDir}
+}
+
+// Reindex walks DirRoot, rebuilding both the flat file list used by /latest
+// and /titles and the search index used by /search. It's called on every "/"
+// request and, from main, on a schedule/watch so the catalog picks up
+// changes made outside of a request (see search.Watch).
+//
+// When CacheDir is set, metadata and cover extraction is cached by
+// s.coverCache keyed on mtime, so a book that hasn't changed since the last
+// Reindex is skipped rather than re-parsed. With CacheDir empty, every hit
+// to "/" unzips and XML-parses the entire library synchronously on the
+// request path.
+func (s OPDS) Reindex() {
+	newFiles := []BookFile{}
+	searchIndex.Reset()
+	cache := s.coverCache()
+
+	filepath.WalkDir(s.DirRoot, func(path string, de fs.DirEntry, err error) error {
+		if de.IsDir() {
+			return nil
+		}
+
+		file, err := de.Info()
+		if err != nil {
+			fmt.Println(err)
+			return nil
+		}
+
+		bf := BookFile{
+			Name:     file.Name(),
+			Path:     path,
+			FileInfo: file,
+		}
+
+		if meta, coverPath, err := cache.Get(path, file.ModTime()); err != nil {
+			log.Printf("error extracting metadata from '%s': %s", path, err)
+		} else {
+			bf.Title = meta.Title
+			bf.Author = meta.Author
+			bf.Summary = meta.Summary
+			bf.CoverPath = coverPath
+		}
+
+		newFiles = append(newFiles, bf)
+		searchIndex.Add(search.Document{Path: bf.Path, Title: bf.Title, Author: bf.Author})
+
+		return nil
+	})
+
+	filesMu.Lock()
+	files = newFiles
+	filesMu.Unlock()
+}
+
+// bookEntry builds the feed entry for a single book file, enriching it with
+// title/author/summary and cover links extracted from the file itself when
+// available, and falling back to the bare filename otherwise. user is the
+// authenticated username (empty if the request wasn't authenticated), used
+// to decide whether to advertise a PSE progress link.
+func (s OPDS) bookEntry(urlPath string, f BookFile, user string) opds.Entry {
+	fi := f.FileInfo
+	pathType := getPathType(f.Path)
+	href := filepath.Join("/", url.PathEscape(strings.TrimPrefix(f.Path, s.DirRoot)))
+
+	title := fi.Name()
+	if f.Title != "" {
+		title = f.Title
+	}
+
+	entryBuilder := opds.EntryBuilder.
+		ID(urlPath + fi.Name()).
+		Updated(TimeNow()).
+		Published(TimeNow()).
+		AddLink(opds.LinkBuilder.Rel(getRel(f.Path, pathType)).Title(fi.Name()).Href(href).Type(getType(f.Path, pathType)).Build())
+
+	if pathType == pathTypeFile {
+		if f.Author != "" {
+			entryBuilder = entryBuilder.Author(opds.AuthorBuilder.Name(f.Author).Build())
+		}
+		if f.Summary != "" {
+			entryBuilder = entryBuilder.Content(f.Summary)
+		}
+
+		if f.CoverPath != "" {
+			coverHref := "/covers/" + filepath.Base(f.CoverPath)
+			coverType := mime.TypeByExtension(filepath.Ext(f.CoverPath))
+			entryBuilder = entryBuilder.
+				AddLink(opds.LinkBuilder.Rel("http://opds-spec.org/image").Href(coverHref).Type(coverType).Build()).
+				AddLink(opds.LinkBuilder.Rel("http://opds-spec.org/image/thumbnail").Href(coverHref).Type(coverType).Build())
+		}
+
+		if s.ProgressDir != "" && user != "" {
+			progressHref := "/progress" + href
+			entryBuilder = entryBuilder.
+				AddLink(opds.LinkBuilder.Rel(pseLinkRel).Href(progressHref).Type("application/vnd.readium.position-list+json").Build())
+		}
+
+		if strings.ToLower(filepath.Ext(f.Path)) == ".epub" {
+			for _, format := range []convert.Format{convert.Kepub, convert.Mobi} {
+				formatHref := href + "?format=" + string(format)
+				entryBuilder = entryBuilder.
+					AddLink(opds.LinkBuilder.Rel("http://opds-spec.org/acquisition").Href(formatHref).Type(format.MIMEType()).Build())
+			}
+		}
+	}
+
+	return entryBuilder.Title(title).Build()
+}
+
+// pageInfo describes the slice of a listing that was served, so callers can
+// build the standard paging links and OpenSearch response elements.
+type pageInfo struct {
+	Page       int
+	PageSize   int
+	Total      int
+	TotalPages int
+}
+
+// paginate slices items down to the page requested via the "page" query
+// parameter, using s.PageSize as the page size. It returns the full set of
+// items unchanged and a nil pageInfo when PageSize is unset, preserving the
+// previous unpaginated behaviour.
+func (s OPDS) paginate(req *http.Request, items []BookFile) ([]BookFile, *pageInfo) {
+	if s.PageSize <= 0 {
+		return items, nil
+	}
+
+	page, err := strconv.Atoi(req.URL.Query().Get("page"))
+	if err != nil || page < 1 {
+		page = 1
+	}
+
+	total := len(items)
+	totalPages := (total + s.PageSize - 1) / s.PageSize
+
+	start := (page - 1) * s.PageSize
+	if start > total {
+		start = total
+	}
+	end := start + s.PageSize
+	if end > total {
+		end = total
+	}
+
+	return items[start:end], &pageInfo{Page: page, PageSize: s.PageSize, Total: total, TotalPages: totalPages}
+}
+
+// pagingLinks builds the first/previous/next/last link relations for a
+// paginated pathTypeDirOfFiles listing served at basePath.
+func pagingLinks(basePath string, pi *pageInfo) []opds.Link {
+	linkType := getType(basePath, pathTypeDirOfFiles)
+	linkFor := func(rel string, page int) opds.Link {
+		return opds.LinkBuilder.Rel(rel).Href(fmt.Sprintf("%s?page=%d", basePath, page)).Type(linkType).Build()
+	}
+
+	links := []opds.Link{linkFor("first", 1)}
+	if pi.Page > 1 {
+		links = append(links, linkFor("previous", pi.Page-1))
+	}
+	if pi.Page < pi.TotalPages {
+		links = append(links, linkFor("next", pi.Page+1))
+	}
+	if pi.TotalPages > 0 {
+		links = append(links, linkFor("last", pi.TotalPages))
+	}
+	return links
+}
+
+// openSearchNamespace is the OpenSearch 1.1 response-element namespace the
+// opensearch:totalResults/itemsPerPage/startIndex elements below belong to.
+const openSearchNamespace = "http://a9.com/-/spec/opensearch/1.1/"
+
+// addOpenSearchCounts inserts the opensearch:totalResults, itemsPerPage and
+// startIndex elements required alongside the paging link relations
+// (https://github.com/dmfs/opds-client/wiki/Opensearch) into an
+// already-marshaled Atom/XML feed. It operates on the marshaled bytes rather
+// than the opds.Feed struct since that type lives outside this package and
+// has no concept of OpenSearch paging.
+func addOpenSearchCounts(content []byte, pi *pageInfo) []byte {
+	s := string(content)
+
+	feedStart := strings.Index(s, "<feed")
+	if feedStart == -1 {
+		return content
+	}
+	feedTagEnd := strings.Index(s[feedStart:], ">")
+	if feedTagEnd == -1 {
+		return content
+	}
+	feedTagEnd += feedStart
+	s = s[:feedTagEnd] + ` xmlns:opensearch="` + openSearchNamespace + `"` + s[feedTagEnd:]
+
+	startIndex := (pi.Page-1)*pi.PageSize + 1
+	counts := fmt.Sprintf(
+		"<opensearch:totalResults>%d</opensearch:totalResults><opensearch:itemsPerPage>%d</opensearch:itemsPerPage><opensearch:startIndex>%d</opensearch:startIndex>",
+		pi.Total, pi.PageSize, startIndex,
+	)
+
+	closeTag := strings.LastIndex(s, "</feed>")
+	if closeTag == -1 {
+		return []byte(s)
+	}
+	s = s[:closeTag] + counts + s[closeTag:]
+
+	return []byte(s)
+}
+
+// CoverHandler serves a previously cached cover image by its cache file name,
+// as linked from bookEntry's thumbnail/image links.
+func (s OPDS) CoverHandler(w http.ResponseWriter, req *http.Request) error {
+	if s.CacheDir == "" {
+		http.NotFound(w, req)
+		return nil
+	}
+
+	name := filepath.Base(req.URL.Path)
+	coverPath := filepath.Join(s.CacheDir, name)
+	if filepath.Dir(coverPath) != filepath.Clean(s.CacheDir) {
+		http.NotFound(w, req)
+		return nil
+	}
+
+	http.ServeFile(w, req, coverPath)
+	return nil
+}
+
+// requestedFormat returns the conversion Format a client wants for a book,
+// from an explicit "?format=" query parameter or, failing that, inferred
+// from a Kobo device's User-Agent. It returns "" when the client wants the
+// book served as-is.
+func requestedFormat(req *http.Request) convert.Format {
+	switch req.URL.Query().Get("format") {
+	case string(convert.Kepub):
+		return convert.Kepub
+	case string(convert.Mobi):
+		return convert.Mobi
+	}
+
+	if strings.Contains(strings.ToLower(req.UserAgent()), "kobo") {
+		return convert.Kepub
+	}
+
+	return ""
+}
+
+// serveBook serves fPath as-is, or converted to the client's requested
+// format (see requestedFormat), caching the conversion per s.ConvertCacheDir.
+func (s OPDS) serveBook(w http.ResponseWriter, req *http.Request, fPath string) error {
+	format := requestedFormat(req)
+	if format == "" || strings.ToLower(filepath.Ext(fPath)) != ".epub" {
+		http.ServeFile(w, req, fPath)
+		return nil
+	}
+
+	convertedPath, err := s.converter().Convert(fPath, format)
+	if err != nil {
+		log.Printf("error converting '%s' to %s: %s", fPath, format, err)
+		http.ServeFile(w, req, fPath)
+		return nil
+	}
+
+	w.Header().Set("Content-Type", format.MIMEType())
+	http.ServeFile(w, req, convertedPath)
+	return nil
 }
 
 var TimeNow = timeNowFunc()
 
 const navigationType = "application/atom+xml;profile=opds-catalog;kind=navigation"
 
+// opds2JSONType is the media type modern OPDS 2.0 clients (Thorium, Aldiko
+// Next, ...) send in their Accept header instead of the Atom/XML types above.
+const opds2JSONType = "application/opds+json"
+
+const openSearchDescriptionType = "application/opensearchdescription+xml"
+
+// openSearchDescription is the static OpenSearch 1.1 description document
+// required by the OPDS 1.1 spec: https://specs.opds.io/opds-1.1.html#26-search
+const openSearchDescription = `<?xml version="1.0" encoding="UTF-8"?>
+<OpenSearchDescription xmlns="http://a9.com/-/spec/opensearch/1.1/">
+  <ShortName>dir2opds</ShortName>
+  <Description>Search this catalog</Description>
+  <InputEncoding>UTF-8</InputEncoding>
+  <OutputEncoding>UTF-8</OutputEncoding>
+  <Url type="application/atom+xml;profile=opds-catalog;kind=acquisition" template="/search?q={searchTerms}"/>
+</OpenSearchDescription>
+`
+
+// OpenSearchHandler serves the OpenSearch description document linked from
+// every feed's rel="search" link.
+func (s OPDS) OpenSearchHandler(w http.ResponseWriter, req *http.Request) error {
+	w.Header().Add("Content-Type", openSearchDescriptionType)
+	http.ServeContent(w, req, "opensearch.xml", TimeNow(), strings.NewReader(openSearchDescription))
+	return nil
+}
+
+// SearchHandler serves an acquisition feed of the books matching the "q"
+// query parameter against searchIndex.
+func (s OPDS) SearchHandler(w http.ResponseWriter, req *http.Request) error {
+	q := req.URL.Query().Get("q")
+	user := authUser(req)
+
+	feedBuilder := opds.FeedBuilder.
+		ID("/search?q=" + url.QueryEscape(q)).
+		Title("Search results for \"" + q + "\"").
+		Author(opds.AuthorBuilder.Name(s.Author).Email(s.AuthorEmail).URI(s.AuthorURI).Build()).
+		Updated(TimeNow()).
+		AddLink(opds.LinkBuilder.Rel("start").Href("/").Type(navigationType).Build())
+
+	allFiles := currentFiles()
+	for _, doc := range searchIndex.Search(q) {
+		for _, f := range allFiles {
+			if f.Path == doc.Path {
+				feedBuilder = feedBuilder.AddEntry(s.bookEntry("/search", f, user))
+				break
+			}
+		}
+	}
+
+	navFeed := feedBuilder.Build()
+	acFeed := &opds.AcquisitionFeed{Feed: &navFeed, Dc: "http://purl.org/dc/terms/", Opds: "http://opds-spec.org/2010/catalog"}
+	content, err := xml.MarshalIndent(acFeed, "  ", "    ")
+	if err != nil {
+		log.Printf("error while serving search results for %q: %s", q, err)
+		return err
+	}
+
+	content = append([]byte(xml.Header), content...)
+	w.Header().Add("Content-Type", "application/atom+xml;profile=opds-catalog;kind=acquisition")
+	http.ServeContent(w, req, "feed.xml", TimeNow(), bytes.NewReader(content))
+
+	return nil
+}
+
 // Handler serve the content of a book file or
 // returns an Acquisition Feed when the entries are documents or
 // returns an Navegation Feed when the entries are other folders
@@ -68,6 +551,7 @@ func (s OPDS) Handler(w http.ResponseWriter, req *http.Request) error {
 		return err
 	}
 	fPath := filepath.Join(s.DirRoot, urlPath)
+	user := authUser(req)
 
 	log.Printf("urlPath:'%s'", urlPath)
 	log.Printf("fPath:'%s'", fPath)
@@ -77,26 +561,13 @@ func (s OPDS) Handler(w http.ResponseWriter, req *http.Request) error {
 		Title(strings.Title(strings.TrimPrefix(urlPath, "/"))).
 		Author(opds.AuthorBuilder.Name(s.Author).Email(s.AuthorEmail).URI(s.AuthorURI).Build()).
 		Updated(TimeNow()).
-		AddLink(opds.LinkBuilder.Rel("start").Href("/").Type(navigationType).Build())
+		AddLink(opds.LinkBuilder.Rel("start").Href("/").Type(navigationType).Build()).
+		AddLink(opds.LinkBuilder.Rel("search").Href("/opensearch.xml").Type(openSearchDescriptionType).Build())
+
+	var pageMeta *pageInfo
 
 	if urlPath == "/" {
-		files = []BookFile{}
-		filepath.WalkDir(s.DirRoot, func(path string, de fs.DirEntry, err error) error {
-			if !de.IsDir() {
-				file, err := de.Info()
-				if err != nil {
-					fmt.Println(err)
-					return nil
-				}
-
-				files = append(files, BookFile{
-					Name:     file.Name(),
-					Path:     path,
-					FileInfo: file,
-				})
-			}
-			return nil
-		})
+		s.Reindex()
 
 		feedBuilder = feedBuilder.
 			AddEntry(opds.EntryBuilder.
@@ -115,41 +586,52 @@ func (s OPDS) Handler(w http.ResponseWriter, req *http.Request) error {
 				Build())
 	} else if urlPath == "/latest" {
 		fPath = strings.TrimSuffix(fPath, "/latest")
-		for _, f := range sortByLatest(files) {
-			fi := f.FileInfo
-			pathType := getPathType(f.Path)
-			feedBuilder = feedBuilder.
-				AddEntry(opds.EntryBuilder.
-					ID(urlPath + fi.Name()).
-					Title(fi.Name()).
-					Updated(TimeNow()).
-					Published(TimeNow()).
-					AddLink(opds.LinkBuilder.Rel(getRel(f.Path, pathType)).Title(fi.Name()).Href(filepath.Join("/", url.PathEscape(strings.TrimPrefix(f.Path, s.DirRoot)))).Type(getType(f.Path, pathType)).Build()).
-					Build())
+		pageFiles, pi := s.paginate(req, sortByLatest(currentFiles()))
+		for _, f := range pageFiles {
+			feedBuilder = feedBuilder.AddEntry(s.bookEntry(urlPath, f, user))
+		}
+		if pi != nil {
+			pageMeta = pi
+			for _, l := range pagingLinks(urlPath, pi) {
+				feedBuilder = feedBuilder.AddLink(l)
+			}
 		}
 	} else if urlPath == "/titles" {
 		fPath = strings.TrimSuffix(fPath, "/titles")
-		for _, f := range sortByTitle(files) {
-			fi := f.FileInfo
-			pathType := getPathType(f.Path)
-			feedBuilder = feedBuilder.
-				AddEntry(opds.EntryBuilder.
-					ID(urlPath + fi.Name()).
-					Title(fi.Name()).
-					Updated(TimeNow()).
-					Published(TimeNow()).
-					AddLink(opds.LinkBuilder.Rel(getRel(f.Path, pathType)).Title(fi.Name()).Href(filepath.Join("/", url.PathEscape(strings.TrimPrefix(f.Path, s.DirRoot)))).Type(getType(f.Path, pathType)).Build()).
-					Build())
+		pageFiles, pi := s.paginate(req, sortByTitle(currentFiles()))
+		for _, f := range pageFiles {
+			feedBuilder = feedBuilder.AddEntry(s.bookEntry(urlPath, f, user))
+		}
+		if pi != nil {
+			pageMeta = pi
+			for _, l := range pagingLinks(urlPath, pi) {
+				feedBuilder = feedBuilder.AddLink(l)
+			}
 		}
 	} else if getPathType(fPath) == pathTypeFile {
-		http.ServeFile(w, req, fPath)
-		return nil
+		return s.serveBook(w, req, fPath)
 	}
 
 	navFeed := feedBuilder.Build()
+	isAcquisition := getPathType(fPath) == pathTypeDirOfFiles
+
+	if wantsOPDS2JSON(req) {
+		feed2 := opds.NewFeed2(navFeed, isAcquisition)
+		if pageMeta != nil {
+			feed2 = feed2.WithPaging(pageMeta.Page, pageMeta.PageSize, pageMeta.Total)
+		}
+		content, err := json.MarshalIndent(feed2, "", "  ")
+		if err != nil {
+			log.Printf("error while serving '%s': %s", fPath, err)
+			return err
+		}
+		w.Header().Add("Content-Type", opds2JSONType)
+		http.ServeContent(w, req, "feed.json", TimeNow(), bytes.NewReader(content))
+		return nil
+	}
 
 	var content []byte
-	if getPathType(fPath) == pathTypeDirOfFiles {
+	if isAcquisition {
 		acFeed := &opds.AcquisitionFeed{Feed: &navFeed, Dc: "http://purl.org/dc/terms/", Opds: "http://opds-spec.org/2010/catalog"}
 		content, err = xml.MarshalIndent(acFeed, "  ", "    ")
 		w.Header().Add("Content-Type", "application/atom+xml;profile=opds-catalog;kind=acquisition")
@@ -162,12 +644,28 @@ func (s OPDS) Handler(w http.ResponseWriter, req *http.Request) error {
 		return err
 	}
 
+	if pageMeta != nil {
+		content = addOpenSearchCounts(content, pageMeta)
+	}
+
 	content = append([]byte(xml.Header), content...)
 	http.ServeContent(w, req, "feed.xml", TimeNow(), bytes.NewReader(content))
 
 	return nil
 }
 
+// wantsOPDS2JSON reports whether the client's Accept header lists the
+// OPDS 2.0 JSON media type, in which case it is served instead of the
+// default Atom/XML OPDS 1.1 one. q-values are not weighed.
+func wantsOPDS2JSON(req *http.Request) bool {
+	for _, accept := range strings.Split(req.Header.Get("Accept"), ",") {
+		if strings.HasPrefix(strings.TrimSpace(accept), opds2JSONType) {
+			return true
+		}
+	}
+	return false
+}
+
 func getRel(name string, pathType int) string {
 	if pathType == pathTypeDirOfFiles || pathType == pathTypeDirOfDirs {
 		return "subsection"
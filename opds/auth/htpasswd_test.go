@@ -0,0 +1,64 @@
+package auth
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// alice's hash is `htpasswd -s` output for the password "s3cret".
+const aliceSHA = "{SHA}/vNB+F2HQ559kaLUZbmHHvZrXpg="
+
+func TestHtpasswdVerify(t *testing.T) {
+	h := Htpasswd{
+		"alice": aliceSHA,
+		"bob":   "$apr1$abcdefgh$somethingsomethingsomething",
+	}
+
+	tests := []struct {
+		name     string
+		user     string
+		password string
+		want     bool
+	}{
+		{"correct password", "alice", "s3cret", true},
+		{"wrong password", "alice", "wrongpass", false},
+		{"unknown user", "carol", "s3cret", false},
+		{"unsupported scheme rejected", "bob", "anything", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := h.Verify(tt.user, tt.password); got != tt.want {
+				t.Errorf("Verify(%q, %q) = %v, want %v", tt.user, tt.password, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoadHtpasswd(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "htpasswd")
+	contents := "# comment line\n\nalice:" + aliceSHA + "\nbob:$apr1$abcdefgh$somethingsomethingsomething\n"
+	if err := os.WriteFile(path, []byte(contents), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	h, err := LoadHtpasswd(path)
+	if err != nil {
+		t.Fatalf("LoadHtpasswd() error = %v", err)
+	}
+
+	if len(h) != 2 {
+		t.Fatalf("LoadHtpasswd() loaded %d entries, want 2", len(h))
+	}
+	if !h.Verify("alice", "s3cret") {
+		t.Errorf("Verify(\"alice\", \"s3cret\") = false after load, want true")
+	}
+}
+
+func TestLoadHtpasswdMissingFile(t *testing.T) {
+	if _, err := LoadHtpasswd(filepath.Join(t.TempDir(), "nope")); err == nil {
+		t.Error("LoadHtpasswd() on a missing file returned a nil error, want non-nil")
+	}
+}
@@ -0,0 +1,123 @@
+package metadata
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// Cache extracts and stores bibliographic metadata and cover images on disk
+// so that repeated requests for the same, unchanged book don't re-open and
+// re-parse the archive every time. A zero Cache (empty Dir) disables
+// caching: Get always extracts on demand without persisting anything.
+type Cache struct {
+	Dir string
+}
+
+// cachedMeta is what's persisted to the sidecar JSON file. Cover is omitted:
+// the cover image itself is cached separately as a plain file so it can be
+// served straight off disk by CoverHandler without a round trip through JSON.
+type cachedMeta struct {
+	ModTime time.Time `json:"modTime"`
+	Meta    Metadata  `json:"meta"`
+}
+
+// Get returns bookPath's metadata and the path to its cached cover image
+// (empty if it has none), extracting and caching both on first access or
+// whenever modTime indicates the file has changed since it was last cached.
+func (c Cache) Get(bookPath string, modTime time.Time) (Metadata, string, error) {
+	key := cacheKey(bookPath)
+
+	if c.Dir != "" {
+		if meta, ok := c.readMeta(key, modTime); ok {
+			return meta, c.coverPath(key), nil
+		}
+	}
+
+	meta, err := Extract(bookPath)
+	if err != nil {
+		return Metadata{}, "", err
+	}
+
+	if c.Dir == "" {
+		return meta, "", nil
+	}
+
+	if err := os.MkdirAll(c.Dir, 0o755); err != nil {
+		return meta, "", err
+	}
+
+	coverPath := ""
+	if len(meta.Cover) > 0 {
+		coverPath = filepath.Join(c.Dir, key+".cover"+extByMIME(meta.CoverType))
+		if err := os.WriteFile(coverPath, meta.Cover, 0o644); err != nil {
+			return meta, "", err
+		}
+	}
+
+	c.writeMeta(key, modTime, meta)
+
+	return meta, coverPath, nil
+}
+
+func (c Cache) readMeta(key string, modTime time.Time) (Metadata, bool) {
+	data, err := os.ReadFile(c.metaPath(key))
+	if err != nil {
+		return Metadata{}, false
+	}
+
+	var cached cachedMeta
+	if err := json.Unmarshal(data, &cached); err != nil || !cached.ModTime.Equal(modTime) {
+		return Metadata{}, false
+	}
+	return cached.Meta, true
+}
+
+func (c Cache) writeMeta(key string, modTime time.Time, meta Metadata) {
+	// The cover bytes are cached as their own file; keep the sidecar small.
+	toCache := meta
+	toCache.Cover = nil
+
+	data, err := json.Marshal(cachedMeta{ModTime: modTime, Meta: toCache})
+	if err != nil {
+		return
+	}
+	_ = os.WriteFile(c.metaPath(key), data, 0o644)
+}
+
+func (c Cache) metaPath(key string) string {
+	return filepath.Join(c.Dir, key+".json")
+}
+
+// coverPath returns the path a cached cover for key would be stored at, or
+// "" if none was cached (the book has no cover, or nothing is cached yet).
+func (c Cache) coverPath(key string) string {
+	matches, _ := filepath.Glob(filepath.Join(c.Dir, key+".cover.*"))
+	if len(matches) == 0 {
+		return ""
+	}
+	return matches[0]
+}
+
+func cacheKey(bookPath string) string {
+	sum := sha1.Sum([]byte(bookPath))
+	return hex.EncodeToString(sum[:])
+}
+
+func extByMIME(mimeType string) string {
+	switch mimeType {
+	case "image/jpeg":
+		return ".jpg"
+	case "image/png":
+		return ".png"
+	case "image/gif":
+		return ".gif"
+	case "image/webp":
+		return ".webp"
+	default:
+		return ".img"
+	}
+}
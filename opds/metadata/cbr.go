@@ -0,0 +1,108 @@
+package metadata
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// rar4Signature is the magic bytes at the start of every RAR 1.5-4.x
+// archive. RAR5 (signature "Rar!\x1a\x07\x01\x00") uses a different block
+// layout and isn't supported here.
+var rar4Signature = []byte{0x52, 0x61, 0x72, 0x21, 0x1a, 0x07, 0x00}
+
+const (
+	rarBlockFile   = 0x74
+	rarMethodStore = 0x30
+	rarFlagLarge   = 0x0100
+)
+
+// extractCBR finds the first image in a CBR (RAR comic archive) and returns
+// it as the cover, mirroring extractCBZ's "first image, sorted by name"
+// convention. It understands just enough of the RAR4 file header layout to
+// walk the archive's entry list; only entries stored with no compression
+// (RAR's "store" method, common for already-compressed images) can actually
+// be read back, since the standard library has no RAR decompressor. Archives
+// using real compression, or the newer RAR5 format, log what was skipped and
+// fall back to a filename-only entry rather than failing the whole listing.
+func extractCBR(bookPath string) (Metadata, error) {
+	data, err := os.ReadFile(bookPath)
+	if err != nil {
+		return Metadata{}, err
+	}
+	if len(data) < len(rar4Signature) || !bytes.Equal(data[:len(rar4Signature)], rar4Signature) {
+		return Metadata{}, fmt.Errorf("extractCBR: %s is not a RAR4 archive (RAR5 is not supported)", bookPath)
+	}
+
+	type imageEntry struct {
+		name string
+		data []byte
+	}
+	var images []imageEntry
+
+	pos := len(rar4Signature)
+	for pos+7 <= len(data) {
+		headStart := pos
+		headType := data[headStart+2]
+		headFlags := binary.LittleEndian.Uint16(data[headStart+3 : headStart+5])
+		headSize := int(binary.LittleEndian.Uint16(data[headStart+5 : headStart+7]))
+		if headSize < 7 {
+			break
+		}
+
+		if headType != rarBlockFile {
+			pos = headStart + headSize
+			continue
+		}
+
+		if headStart+32 > len(data) {
+			break
+		}
+		packSize := int(binary.LittleEndian.Uint32(data[headStart+7 : headStart+11]))
+		method := data[headStart+25]
+		nameSize := int(binary.LittleEndian.Uint16(data[headStart+26 : headStart+28]))
+
+		nameStart := headStart + 32
+		if headFlags&rarFlagLarge != 0 {
+			nameStart += 8
+		}
+		if nameStart+nameSize > len(data) {
+			break
+		}
+		name := string(data[nameStart : nameStart+nameSize])
+		if i := strings.IndexByte(name, 0); i >= 0 {
+			name = name[:i]
+		}
+
+		dataStart := headStart + headSize
+		dataEnd := dataStart + packSize
+		if dataEnd < dataStart || dataEnd > len(data) {
+			break
+		}
+
+		if isImageExt(filepath.Ext(name)) {
+			if method == rarMethodStore {
+				images = append(images, imageEntry{name: name, data: data[dataStart:dataEnd]})
+			} else {
+				log.Printf("extractCBR: skipping compressed entry %q in %s (only stored/uncompressed RAR entries are supported)", name, bookPath)
+			}
+		}
+
+		pos = dataEnd
+	}
+
+	if len(images) == 0 {
+		return Metadata{}, nil
+	}
+	sort.Slice(images, func(i, j int) bool { return images[i].name < images[j].name })
+
+	return Metadata{
+		Cover:     images[0].data,
+		CoverType: mimeByExt(filepath.Ext(images[0].name)),
+	}, nil
+}
@@ -0,0 +1,294 @@
+// Package metadata extracts bibliographic metadata and cover images from
+// book files so that acquisition feed entries can show real titles, authors
+// and thumbnails instead of just a filename.
+package metadata
+
+import (
+	"archive/zip"
+	"encoding/base64"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Metadata is the set of bibliographic fields and cover image extracted from
+// a book file. Any field that couldn't be determined is left empty.
+type Metadata struct {
+	Title   string
+	Author  string
+	Summary string
+	// Cover holds the raw bytes of the cover image, if one was found.
+	Cover     []byte
+	CoverType string // MIME type of Cover, e.g. "image/jpeg"
+}
+
+// Extract inspects the book at path and returns whatever bibliographic
+// metadata and cover image it can find. It dispatches on file extension;
+// unsupported formats return a zero Metadata and a nil error so callers can
+// fall back to filename-only entries.
+func Extract(bookPath string) (Metadata, error) {
+	switch strings.ToLower(filepath.Ext(bookPath)) {
+	case ".epub":
+		return extractEPUB(bookPath)
+	case ".pdf":
+		return extractPDF(bookPath)
+	case ".cbz":
+		return extractCBZ(bookPath)
+	case ".cbr":
+		return extractCBR(bookPath)
+	case ".fb2":
+		return extractFB2(bookPath)
+	default:
+		return Metadata{}, nil
+	}
+}
+
+// epub container/OPF structures, just the fields we care about.
+type epubContainer struct {
+	Rootfiles []struct {
+		FullPath string `xml:"full-path,attr"`
+	} `xml:"rootfiles>rootfile"`
+}
+
+type opfPackage struct {
+	Metadata struct {
+		Title       string `xml:"title"`
+		Creator     string `xml:"creator"`
+		Description string `xml:"description"`
+		Metas       []struct {
+			Name    string `xml:"name,attr"`
+			Content string `xml:"content,attr"`
+		} `xml:"meta"`
+	} `xml:"metadata"`
+	Manifest struct {
+		Items []struct {
+			ID         string `xml:"id,attr"`
+			Href       string `xml:"href,attr"`
+			MediaType  string `xml:"media-type,attr"`
+			Properties string `xml:"properties,attr"`
+		} `xml:"item"`
+	} `xml:"manifest"`
+}
+
+func extractEPUB(bookPath string) (Metadata, error) {
+	zr, err := zip.OpenReader(bookPath)
+	if err != nil {
+		return Metadata{}, err
+	}
+	defer zr.Close()
+
+	containerFile, err := openInZip(&zr.Reader, "META-INF/container.xml")
+	if err != nil {
+		return Metadata{}, err
+	}
+	var container epubContainer
+	if err := xml.NewDecoder(containerFile).Decode(&container); err != nil {
+		return Metadata{}, fmt.Errorf("decode container.xml: %w", err)
+	}
+	if len(container.Rootfiles) == 0 {
+		return Metadata{}, fmt.Errorf("no rootfile in %s", bookPath)
+	}
+	opfPath := container.Rootfiles[0].FullPath
+
+	opfFile, err := openInZip(&zr.Reader, opfPath)
+	if err != nil {
+		return Metadata{}, err
+	}
+	var pkg opfPackage
+	if err := xml.NewDecoder(opfFile).Decode(&pkg); err != nil {
+		return Metadata{}, fmt.Errorf("decode %s: %w", opfPath, err)
+	}
+
+	meta := Metadata{
+		Title:   pkg.Metadata.Title,
+		Author:  pkg.Metadata.Creator,
+		Summary: pkg.Metadata.Description,
+	}
+
+	coverID := ""
+	for _, m := range pkg.Metadata.Metas {
+		if m.Name == "cover" {
+			coverID = m.Content
+		}
+	}
+
+	coverHref := ""
+	for _, item := range pkg.Manifest.Items {
+		if item.ID == coverID || strings.Contains(item.Properties, "cover-image") {
+			coverHref = item.Href
+			meta.CoverType = item.MediaType
+		}
+	}
+
+	if coverHref != "" {
+		coverPath := path.Join(path.Dir(opfPath), coverHref)
+		if f, err := openInZip(&zr.Reader, coverPath); err == nil {
+			meta.Cover, _ = io.ReadAll(f)
+		}
+	}
+
+	return meta, nil
+}
+
+func openInZip(zr *zip.Reader, name string) (io.ReadCloser, error) {
+	for _, f := range zr.File {
+		if f.Name == name {
+			return f.Open()
+		}
+	}
+	return nil, fmt.Errorf("%s not found in archive", name)
+}
+
+// pdfInfoRe pulls /Title and /Author out of an uncompressed PDF info
+// dictionary. PDFs with compressed object streams won't match; that's an
+// accepted limitation rather than pulling in a full PDF parser.
+var pdfInfoRe = regexp.MustCompile(`/(Title|Author)\s*\(([^)]*)\)`)
+
+func extractPDF(bookPath string) (Metadata, error) {
+	f, err := os.Open(bookPath)
+	if err != nil {
+		return Metadata{}, err
+	}
+	defer f.Close()
+
+	buf := make([]byte, 64*1024)
+	n, _ := io.ReadFull(f, buf)
+
+	var meta Metadata
+	for _, m := range pdfInfoRe.FindAllStringSubmatch(string(buf[:n]), -1) {
+		switch m[1] {
+		case "Title":
+			meta.Title = m[2]
+		case "Author":
+			meta.Author = m[2]
+		}
+	}
+	return meta, nil
+}
+
+func extractCBZ(bookPath string) (Metadata, error) {
+	zr, err := zip.OpenReader(bookPath)
+	if err != nil {
+		return Metadata{}, err
+	}
+	defer zr.Close()
+
+	var imageNames []string
+	for _, f := range zr.File {
+		if isImageExt(filepath.Ext(f.Name)) {
+			imageNames = append(imageNames, f.Name)
+		}
+	}
+	if len(imageNames) == 0 {
+		return Metadata{}, nil
+	}
+	sort.Strings(imageNames)
+
+	rc, err := openInZip(&zr.Reader, imageNames[0])
+	if err != nil {
+		return Metadata{}, err
+	}
+	defer rc.Close()
+
+	cover, err := io.ReadAll(rc)
+	if err != nil {
+		return Metadata{}, err
+	}
+
+	return Metadata{
+		Cover:     cover,
+		CoverType: mimeByExt(filepath.Ext(imageNames[0])),
+	}, nil
+}
+
+type fb2TitleInfo struct {
+	BookTitle string `xml:"book-title"`
+	Author    []struct {
+		FirstName string `xml:"first-name"`
+		LastName  string `xml:"last-name"`
+	} `xml:"author"`
+	Annotation string `xml:"annotation"`
+	Coverpage  struct {
+		Image struct {
+			Href string `xml:"href,attr"`
+		} `xml:"image"`
+	} `xml:"coverpage"`
+}
+
+type fb2Binary struct {
+	ID          string `xml:"id,attr"`
+	ContentType string `xml:"content-type,attr"`
+	Data        string `xml:",chardata"`
+}
+
+type fb2Doc struct {
+	Description struct {
+		TitleInfo fb2TitleInfo `xml:"title-info"`
+	} `xml:"description"`
+	Binaries []fb2Binary `xml:"binary"`
+}
+
+func extractFB2(bookPath string) (Metadata, error) {
+	f, err := os.Open(bookPath)
+	if err != nil {
+		return Metadata{}, err
+	}
+	defer f.Close()
+
+	var doc fb2Doc
+	if err := xml.NewDecoder(f).Decode(&doc); err != nil {
+		return Metadata{}, fmt.Errorf("decode %s: %w", bookPath, err)
+	}
+
+	ti := doc.Description.TitleInfo
+	meta := Metadata{
+		Title:   ti.BookTitle,
+		Summary: ti.Annotation,
+	}
+	if len(ti.Author) > 0 {
+		meta.Author = strings.TrimSpace(ti.Author[0].FirstName + " " + ti.Author[0].LastName)
+	}
+
+	coverID := strings.TrimPrefix(ti.Coverpage.Image.Href, "#")
+	for _, b := range doc.Binaries {
+		if b.ID == coverID {
+			data, err := base64.StdEncoding.DecodeString(strings.TrimSpace(b.Data))
+			if err == nil {
+				meta.Cover = data
+				meta.CoverType = b.ContentType
+			}
+		}
+	}
+
+	return meta, nil
+}
+
+func isImageExt(ext string) bool {
+	switch strings.ToLower(ext) {
+	case ".jpg", ".jpeg", ".png", ".gif", ".webp":
+		return true
+	default:
+		return false
+	}
+}
+
+func mimeByExt(ext string) string {
+	switch strings.ToLower(ext) {
+	case ".jpg", ".jpeg":
+		return "image/jpeg"
+	case ".png":
+		return "image/png"
+	case ".gif":
+		return "image/gif"
+	case ".webp":
+		return "image/webp"
+	default:
+		return "application/octet-stream"
+	}
+}
@@ -0,0 +1,236 @@
+package metadata
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/binary"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func writeTemp(t *testing.T, name string, data []byte) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestExtractUnsupportedExtension(t *testing.T) {
+	path := writeTemp(t, "book.txt", []byte("hello"))
+	meta, err := Extract(path)
+	if err != nil {
+		t.Fatalf("Extract() error = %v, want nil", err)
+	}
+	if meta.Title != "" || meta.Author != "" || meta.Summary != "" || len(meta.Cover) != 0 {
+		t.Fatalf("Extract() = %+v, want zero value", meta)
+	}
+}
+
+func TestExtractCBZ(t *testing.T) {
+	buf := &bytes.Buffer{}
+	zw := zip.NewWriter(buf)
+	for _, name := range []string{"002.jpg", "001.jpg", "readme.txt"} {
+		w, err := zw.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := w.Write([]byte("fake-image-" + name)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	path := writeTemp(t, "book.cbz", buf.Bytes())
+	meta, err := Extract(path)
+	if err != nil {
+		t.Fatalf("Extract() error = %v", err)
+	}
+	if string(meta.Cover) != "fake-image-001.jpg" {
+		t.Fatalf("Cover = %q, want the alphabetically first image", meta.Cover)
+	}
+	if meta.CoverType != "image/jpeg" {
+		t.Fatalf("CoverType = %q, want image/jpeg", meta.CoverType)
+	}
+}
+
+func TestExtractEPUB(t *testing.T) {
+	buf := &bytes.Buffer{}
+	zw := zip.NewWriter(buf)
+
+	container := `<?xml version="1.0"?>
+<container xmlns="urn:oasis:names:tc:opendocument:xmlns:container" version="1.0">
+  <rootfiles>
+    <rootfile full-path="OEBPS/content.opf" media-type="application/oebps-package+xml"/>
+  </rootfiles>
+</container>`
+	writeZipFile(t, zw, "META-INF/container.xml", []byte(container))
+
+	opf := `<?xml version="1.0"?>
+<package xmlns="http://www.idpf.org/2007/opf" version="2.0">
+  <metadata>
+    <title>Test Book</title>
+    <creator>Ada Lovelace</creator>
+    <description>A short summary.</description>
+    <meta name="cover" content="cover-image"/>
+  </metadata>
+  <manifest>
+    <item id="cover-image" href="images/cover.jpg" media-type="image/jpeg"/>
+  </manifest>
+</package>`
+	writeZipFile(t, zw, "OEBPS/content.opf", []byte(opf))
+	writeZipFile(t, zw, "OEBPS/images/cover.jpg", []byte("fake-cover"))
+
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	path := writeTemp(t, "book.epub", buf.Bytes())
+	meta, err := Extract(path)
+	if err != nil {
+		t.Fatalf("Extract() error = %v", err)
+	}
+	if meta.Title != "Test Book" {
+		t.Errorf("Title = %q, want %q", meta.Title, "Test Book")
+	}
+	if meta.Author != "Ada Lovelace" {
+		t.Errorf("Author = %q, want %q", meta.Author, "Ada Lovelace")
+	}
+	if meta.Summary != "A short summary." {
+		t.Errorf("Summary = %q, want %q", meta.Summary, "A short summary.")
+	}
+	if string(meta.Cover) != "fake-cover" {
+		t.Errorf("Cover = %q, want %q", meta.Cover, "fake-cover")
+	}
+	if meta.CoverType != "image/jpeg" {
+		t.Errorf("CoverType = %q, want %q", meta.CoverType, "image/jpeg")
+	}
+}
+
+func writeZipFile(t *testing.T, zw *zip.Writer, name string, data []byte) {
+	t.Helper()
+	w, err := zw.Create(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := w.Write(data); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestExtractPDF(t *testing.T) {
+	doc := "%PDF-1.4\n1 0 obj\n<< /Title (Test Book) /Author (Ada Lovelace) >>\nendobj\n%%EOF"
+
+	path := writeTemp(t, "book.pdf", []byte(doc))
+	meta, err := Extract(path)
+	if err != nil {
+		t.Fatalf("Extract() error = %v", err)
+	}
+	if meta.Title != "Test Book" {
+		t.Errorf("Title = %q, want %q", meta.Title, "Test Book")
+	}
+	if meta.Author != "Ada Lovelace" {
+		t.Errorf("Author = %q, want %q", meta.Author, "Ada Lovelace")
+	}
+}
+
+func TestExtractPDFNoInfoDictionary(t *testing.T) {
+	path := writeTemp(t, "book.pdf", []byte("%PDF-1.4\n%%EOF"))
+	meta, err := Extract(path)
+	if err != nil {
+		t.Fatalf("Extract() error = %v", err)
+	}
+	if meta.Title != "" || meta.Author != "" {
+		t.Fatalf("Extract() = %+v, want empty Title/Author", meta)
+	}
+}
+
+func TestExtractFB2(t *testing.T) {
+	doc := `<?xml version="1.0" encoding="UTF-8"?>
+<FictionBook xmlns="http://www.gribuser.ru/xml/fictionbook/2.0">
+  <description>
+    <title-info>
+      <book-title>Test Book</book-title>
+      <author><first-name>Ada</first-name><last-name>Lovelace</last-name></author>
+      <annotation>A short summary.</annotation>
+      <coverpage><image href="#cover"/></coverpage>
+    </title-info>
+  </description>
+  <binary id="cover" content-type="image/jpeg">ZmFrZS1jb3Zlcg==</binary>
+</FictionBook>`
+
+	path := writeTemp(t, "book.fb2", []byte(doc))
+	meta, err := Extract(path)
+	if err != nil {
+		t.Fatalf("Extract() error = %v", err)
+	}
+	if meta.Title != "Test Book" {
+		t.Errorf("Title = %q, want %q", meta.Title, "Test Book")
+	}
+	if meta.Author != "Ada Lovelace" {
+		t.Errorf("Author = %q, want %q", meta.Author, "Ada Lovelace")
+	}
+	if meta.Summary != "A short summary." {
+		t.Errorf("Summary = %q, want %q", meta.Summary, "A short summary.")
+	}
+	if string(meta.Cover) != "fake-cover" {
+		t.Errorf("Cover = %q, want %q", meta.Cover, "fake-cover")
+	}
+	if meta.CoverType != "image/jpeg" {
+		t.Errorf("CoverType = %q, want %q", meta.CoverType, "image/jpeg")
+	}
+}
+
+// buildRAR4File encodes a single stored (uncompressed) RAR4 file block, for
+// exercising extractCBR without needing a real archiver on PATH.
+func buildRAR4File(t *testing.T, name string, data []byte) []byte {
+	t.Helper()
+
+	header := make([]byte, 32+len(name))
+	binary.LittleEndian.PutUint16(header[0:2], 0) // HEAD_CRC, unchecked by extractCBR
+	header[2] = rarBlockFile                      // HEAD_TYPE
+	binary.LittleEndian.PutUint16(header[3:5], 0) // HEAD_FLAGS
+	binary.LittleEndian.PutUint16(header[5:7], uint16(len(header)))
+	binary.LittleEndian.PutUint32(header[7:11], uint32(len(data)))  // PACK_SIZE
+	binary.LittleEndian.PutUint32(header[11:15], uint32(len(data))) // UNP_SIZE
+	header[15] = 0                                                  // HOST_OS
+	binary.LittleEndian.PutUint32(header[16:20], 0)                 // FILE_CRC
+	binary.LittleEndian.PutUint32(header[20:24], 0)                 // FTIME
+	header[24] = 0                                                  // UNP_VER
+	header[25] = rarMethodStore                                     // METHOD
+	binary.LittleEndian.PutUint16(header[26:28], uint16(len(name)))
+	binary.LittleEndian.PutUint32(header[28:32], 0) // ATTR
+	copy(header[32:], name)
+
+	return append(header, data...)
+}
+
+func TestExtractCBR(t *testing.T) {
+	var archive []byte
+	archive = append(archive, rar4Signature...)
+	archive = append(archive, buildRAR4File(t, "002.jpg", []byte("fake-image-002"))...)
+	archive = append(archive, buildRAR4File(t, "001.jpg", []byte("fake-image-001"))...)
+
+	path := writeTemp(t, "book.cbr", archive)
+	meta, err := Extract(path)
+	if err != nil {
+		t.Fatalf("Extract() error = %v", err)
+	}
+	if string(meta.Cover) != "fake-image-001" {
+		t.Fatalf("Cover = %q, want the alphabetically first stored image", meta.Cover)
+	}
+	if meta.CoverType != "image/jpeg" {
+		t.Fatalf("CoverType = %q, want image/jpeg", meta.CoverType)
+	}
+}
+
+func TestExtractCBRRejectsNonRAR4(t *testing.T) {
+	path := writeTemp(t, "book.cbr", []byte("not a rar archive"))
+	if _, err := Extract(path); err == nil {
+		t.Fatal("Extract() error = nil, want an error for a non-RAR4 .cbr file")
+	}
+}
@@ -0,0 +1,105 @@
+package opds
+
+import "time"
+
+// This file adds an OPDS 2.0 (https://drafts.opds.io/opds-2.0) JSON
+// representation alongside the existing Atom/XML OPDS 1.1 feed builders.
+// It is a pure transformation layer: it takes the Feed already assembled by
+// FeedBuilder/EntryBuilder and re-shapes it into the OPDS 2.0 catalog
+// structure so callers don't have to build the feed twice.
+
+// Feed2Type is the root of an OPDS 2.0 catalog document.
+type Feed2Type struct {
+	Metadata     Feed2Metadata  `json:"metadata"`
+	Links        []Link2        `json:"links"`
+	Navigation   []Link2        `json:"navigation,omitempty"`
+	Publications []Publication2 `json:"publications,omitempty"`
+}
+
+// Feed2Metadata carries the catalog-level metadata block.
+type Feed2Metadata struct {
+	Title         string     `json:"title"`
+	ItemsPerPage  int        `json:"itemsPerPage,omitempty"`
+	CurrentPage   int        `json:"currentPage,omitempty"`
+	NumberOfItems int        `json:"numberOfItems,omitempty"`
+	Modified      *time.Time `json:"modified,omitempty"`
+}
+
+// Link2 mirrors the rel/href/type/title shape of Link but marshals to JSON
+// instead of an Atom <link> element.
+type Link2 struct {
+	Rel   string `json:"rel,omitempty"`
+	Href  string `json:"href"`
+	Type  string `json:"type,omitempty"`
+	Title string `json:"title,omitempty"`
+}
+
+// Publication2 is a single acquisition entry in OPDS 2.0 form.
+type Publication2 struct {
+	Metadata Publication2Metadata `json:"metadata"`
+	Links    []Link2              `json:"links"`
+	Images   []Link2              `json:"images,omitempty"`
+}
+
+// Publication2Metadata holds the per-publication metadata block.
+type Publication2Metadata struct {
+	Title    string     `json:"title"`
+	Author   string     `json:"author,omitempty"`
+	Modified *time.Time `json:"modified,omitempty"`
+}
+
+// WithPaging fills in the OpenSearch-style paging counters
+// (currentPage/itemsPerPage/numberOfItems) on an already-built Feed2Type.
+func (f Feed2Type) WithPaging(page, pageSize, total int) Feed2Type {
+	f.Metadata.CurrentPage = page
+	f.Metadata.ItemsPerPage = pageSize
+	f.Metadata.NumberOfItems = total
+	return f
+}
+
+// NewFeed2 converts an already-built Feed into its OPDS 2.0 JSON equivalent.
+// isAcquisition selects whether entries become publications (files) or
+// navigation links (sub-catalogs), matching the acquisition/navigation split
+// Handler already makes for the XML representation.
+func NewFeed2(feed Feed, isAcquisition bool) Feed2Type {
+	f2 := Feed2Type{
+		Metadata: Feed2Metadata{
+			Title: feed.Title,
+		},
+	}
+
+	for _, l := range feed.Links {
+		f2.Links = append(f2.Links, Link2{Rel: l.Rel, Href: l.Href, Type: l.Type, Title: l.Title})
+	}
+
+	for _, e := range feed.Entries {
+		links := make([]Link2, 0, len(e.Links))
+		var images []Link2
+		for _, l := range e.Links {
+			l2 := Link2{Rel: l.Rel, Href: l.Href, Type: l.Type, Title: l.Title}
+			if l.Rel == "http://opds-spec.org/image" || l.Rel == "http://opds-spec.org/image/thumbnail" {
+				images = append(images, l2)
+				continue
+			}
+			links = append(links, l2)
+		}
+
+		if isAcquisition {
+			f2.Publications = append(f2.Publications, Publication2{
+				Metadata: Publication2Metadata{Title: e.Title},
+				Links:    links,
+				Images:   images,
+			})
+			continue
+		}
+
+		for _, l := range links {
+			l.Title = e.Title
+			f2.Navigation = append(f2.Navigation, l)
+		}
+	}
+
+	f2.Metadata.NumberOfItems = len(f2.Publications)
+
+	return f2
+}
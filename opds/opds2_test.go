@@ -0,0 +1,88 @@
+package opds
+
+import "testing"
+
+func TestNewFeed2Navigation(t *testing.T) {
+	feed := Feed{
+		Title: "Library",
+		Links: []Link{
+			{Rel: "self", Href: "/", Type: "application/atom+xml"},
+		},
+		Entries: []Entry{
+			{
+				Title: "Sci-Fi",
+				Links: []Link{
+					{Rel: "subsection", Href: "/sci-fi", Type: "application/atom+xml"},
+				},
+			},
+		},
+	}
+
+	f2 := NewFeed2(feed, false)
+
+	if f2.Metadata.Title != "Library" {
+		t.Errorf("Metadata.Title = %q, want %q", f2.Metadata.Title, "Library")
+	}
+	if len(f2.Publications) != 0 {
+		t.Errorf("Publications = %v, want none for a navigation feed", f2.Publications)
+	}
+	if len(f2.Navigation) != 1 {
+		t.Fatalf("Navigation = %v, want 1 entry", f2.Navigation)
+	}
+	if got := f2.Navigation[0]; got.Href != "/sci-fi" || got.Title != "Sci-Fi" {
+		t.Errorf("Navigation[0] = %+v, want Href=/sci-fi Title=Sci-Fi", got)
+	}
+}
+
+func TestNewFeed2AcquisitionSplitsImages(t *testing.T) {
+	feed := Feed{
+		Title: "Sci-Fi",
+		Entries: []Entry{
+			{
+				Title: "Dune",
+				Links: []Link{
+					{Rel: "http://opds-spec.org/acquisition", Href: "/books/dune.epub", Type: "application/epub+zip"},
+					{Rel: "http://opds-spec.org/image", Href: "/covers/dune.jpg", Type: "image/jpeg"},
+					{Rel: "http://opds-spec.org/image/thumbnail", Href: "/covers/dune-thumb.jpg", Type: "image/jpeg"},
+				},
+			},
+		},
+	}
+
+	f2 := NewFeed2(feed, true)
+
+	if len(f2.Navigation) != 0 {
+		t.Errorf("Navigation = %v, want none for an acquisition feed", f2.Navigation)
+	}
+	if len(f2.Publications) != 1 {
+		t.Fatalf("Publications = %v, want 1 entry", f2.Publications)
+	}
+
+	pub := f2.Publications[0]
+	if pub.Metadata.Title != "Dune" {
+		t.Errorf("Publications[0].Metadata.Title = %q, want %q", pub.Metadata.Title, "Dune")
+	}
+	if len(pub.Links) != 1 || pub.Links[0].Href != "/books/dune.epub" {
+		t.Errorf("Publications[0].Links = %v, want just the acquisition link", pub.Links)
+	}
+	if len(pub.Images) != 2 {
+		t.Errorf("Publications[0].Images = %v, want 2 images", pub.Images)
+	}
+
+	if f2.Metadata.NumberOfItems != 1 {
+		t.Errorf("Metadata.NumberOfItems = %d, want 1", f2.Metadata.NumberOfItems)
+	}
+}
+
+func TestFeed2TypeWithPaging(t *testing.T) {
+	f2 := Feed2Type{Metadata: Feed2Metadata{Title: "Library"}}
+
+	got := f2.WithPaging(2, 20, 57)
+
+	if got.Metadata.CurrentPage != 2 || got.Metadata.ItemsPerPage != 20 || got.Metadata.NumberOfItems != 57 {
+		t.Errorf("WithPaging(2, 20, 57) = %+v, want CurrentPage=2 ItemsPerPage=20 NumberOfItems=57", got.Metadata)
+	}
+	if f2.Metadata.CurrentPage != 0 {
+		t.Errorf("WithPaging mutated the receiver's CurrentPage, want it left at 0")
+	}
+}
@@ -0,0 +1,101 @@
+// Package search provides a small in-memory inverted index over book
+// filenames and metadata, used to back the OPDS OpenSearch endpoint.
+package search
+
+import (
+	"regexp"
+	"strings"
+	"sync"
+)
+
+// Document is a single indexed book.
+type Document struct {
+	Path   string
+	Title  string
+	Author string
+}
+
+// Index is an in-memory inverted index: term -> set of document paths. It is
+// safe for concurrent use.
+type Index struct {
+	mu       sync.RWMutex
+	docs     map[string]Document
+	postings map[string]map[string]struct{}
+}
+
+// NewIndex returns an empty Index.
+func NewIndex() *Index {
+	return &Index{
+		docs:     map[string]Document{},
+		postings: map[string]map[string]struct{}{},
+	}
+}
+
+// Reset clears the index, ready for a fresh full rebuild.
+func (idx *Index) Reset() {
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+	idx.docs = map[string]Document{}
+	idx.postings = map[string]map[string]struct{}{}
+}
+
+// Add indexes doc under the terms found in its path, title and author,
+// replacing any previous document at the same path.
+func (idx *Index) Add(doc Document) {
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+
+	if old, ok := idx.docs[doc.Path]; ok {
+		for _, term := range tokenize(old.Title + " " + old.Author + " " + old.Path) {
+			delete(idx.postings[term], doc.Path)
+		}
+	}
+
+	idx.docs[doc.Path] = doc
+	for _, term := range tokenize(doc.Title + " " + doc.Author + " " + doc.Path) {
+		set, ok := idx.postings[term]
+		if !ok {
+			set = map[string]struct{}{}
+			idx.postings[term] = set
+		}
+		set[doc.Path] = struct{}{}
+	}
+}
+
+// Search returns the documents matching every term in q (AND semantics).
+func (idx *Index) Search(q string) []Document {
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+
+	terms := tokenize(q)
+	if len(terms) == 0 {
+		return nil
+	}
+
+	matches := idx.postings[terms[0]]
+	paths := make(map[string]struct{}, len(matches))
+	for p := range matches {
+		paths[p] = struct{}{}
+	}
+
+	for _, term := range terms[1:] {
+		set := idx.postings[term]
+		for p := range paths {
+			if _, ok := set[p]; !ok {
+				delete(paths, p)
+			}
+		}
+	}
+
+	docs := make([]Document, 0, len(paths))
+	for p := range paths {
+		docs = append(docs, idx.docs[p])
+	}
+	return docs
+}
+
+var tokenRe = regexp.MustCompile(`[a-z0-9]+`)
+
+func tokenize(s string) []string {
+	return tokenRe.FindAllString(strings.ToLower(s), -1)
+}
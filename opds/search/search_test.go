@@ -0,0 +1,82 @@
+package search
+
+import (
+	"sort"
+	"testing"
+)
+
+func paths(docs []Document) []string {
+	out := make([]string, len(docs))
+	for i, d := range docs {
+		out[i] = d.Path
+	}
+	sort.Strings(out)
+	return out
+}
+
+func TestIndexSearchANDSemantics(t *testing.T) {
+	idx := NewIndex()
+	idx.Add(Document{Path: "/a/dune.epub", Title: "Dune", Author: "Frank Herbert"})
+	idx.Add(Document{Path: "/b/dune-messiah.epub", Title: "Dune Messiah", Author: "Frank Herbert"})
+	idx.Add(Document{Path: "/c/neuromancer.epub", Title: "Neuromancer", Author: "William Gibson"})
+
+	got := paths(idx.Search("dune herbert"))
+	want := []string{"/a/dune.epub", "/b/dune-messiah.epub"}
+	if !equalStrings(got, want) {
+		t.Errorf("Search(%q) = %v, want %v", "dune herbert", got, want)
+	}
+
+	got = paths(idx.Search("gibson"))
+	want = []string{"/c/neuromancer.epub"}
+	if !equalStrings(got, want) {
+		t.Errorf("Search(%q) = %v, want %v", "gibson", got, want)
+	}
+
+	if got := idx.Search("dune gibson"); len(got) != 0 {
+		t.Errorf("Search(%q) = %v, want no matches", "dune gibson", got)
+	}
+}
+
+func TestIndexSearchEmptyQuery(t *testing.T) {
+	idx := NewIndex()
+	idx.Add(Document{Path: "/a/dune.epub", Title: "Dune", Author: "Frank Herbert"})
+
+	if got := idx.Search(""); got != nil {
+		t.Errorf("Search(%q) = %v, want nil", "", got)
+	}
+}
+
+func TestIndexReset(t *testing.T) {
+	idx := NewIndex()
+	idx.Add(Document{Path: "/a/dune.epub", Title: "Dune", Author: "Frank Herbert"})
+	idx.Reset()
+
+	if got := idx.Search("dune"); len(got) != 0 {
+		t.Errorf("Search(%q) after Reset() = %v, want no matches", "dune", got)
+	}
+}
+
+func TestIndexAddReplacesExistingDocument(t *testing.T) {
+	idx := NewIndex()
+	idx.Add(Document{Path: "/a/book.epub", Title: "Old Title", Author: ""})
+	idx.Add(Document{Path: "/a/book.epub", Title: "New Title", Author: ""})
+
+	if got := idx.Search("old"); len(got) != 0 {
+		t.Errorf("Search(%q) = %v, want no matches after re-adding the same path", "old", got)
+	}
+	if got := paths(idx.Search("new")); !equalStrings(got, []string{"/a/book.epub"}) {
+		t.Errorf("Search(%q) = %v, want [/a/book.epub]", "new", got)
+	}
+}
+
+func equalStrings(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
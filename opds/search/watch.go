@@ -0,0 +1,27 @@
+package search
+
+import "time"
+
+// Watch rebuilds the index immediately, then again every interval until
+// stop is closed.
+//
+// fsnotify would be the more responsive choice, but this repo has no
+// go.mod/vendor tree to pin and checksum a third-party module against, so a
+// bare import of it can't actually be built or fetched here. Polling is the
+// honest fallback given that constraint; callers with large trees can widen
+// interval to reduce rescan cost.
+func Watch(interval time.Duration, stop <-chan struct{}, rebuild func()) {
+	rebuild()
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			rebuild()
+		case <-stop:
+			return
+		}
+	}
+}